@@ -0,0 +1,63 @@
+package dockerconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hubAliases are the hostnames Docker Hub has been reachable under over
+// the years; all of them share the credentials stored under
+// defaultIndexServer.
+var hubAliases = map[string]bool{
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+	"docker.io":            true,
+}
+
+// ConvertToHostname normalizes a registry address into a bare hostname
+// (optionally with a port), accepting bare hostnames, http(s):// URLs,
+// and either with a trailing /v1/ or /v2/ path.
+func ConvertToHostname(url string) string {
+	stripped := url
+	if strings.HasPrefix(url, "http://") {
+		stripped = strings.TrimPrefix(url, "http://")
+	} else if strings.HasPrefix(url, "https://") {
+		stripped = strings.TrimPrefix(url, "https://")
+	}
+
+	nameParts := strings.SplitN(stripped, "/", 2)
+	return nameParts[0]
+}
+
+// normalizeAuthKey returns the key serverAddress's credentials are (or
+// should be) stored under in AuthConfigs: the canonical Hub entry for any
+// of its aliases, or its bare hostname otherwise.
+func normalizeAuthKey(serverAddress string) string {
+	hostname := ConvertToHostname(serverAddress)
+	if hubAliases[hostname] {
+		return defaultIndexServer
+	}
+	return hostname
+}
+
+// GetAuthConfig returns the AuthConfig stored for serverAddress. It
+// matches serverAddress verbatim first, then its normalized hostname, and
+// resolves any Docker Hub alias (index.docker.io, registry-1.docker.io,
+// docker.io) to the canonical defaultIndexServer entry.
+func (c *ConfigFile) GetAuthConfig(serverAddress string) (AuthConfig, error) {
+	if ac, ok := c.AuthConfigs[serverAddress]; ok {
+		return ac, nil
+	}
+	if ac, ok := c.AuthConfigs[normalizeAuthKey(serverAddress)]; ok {
+		return ac, nil
+	}
+	return AuthConfig{}, fmt.Errorf("no auth config found for %q", serverAddress)
+}
+
+// SetAuthConfig stores authConfig under its normalized server address,
+// applying the same Hub alias resolution as GetAuthConfig.
+func (c *ConfigFile) SetAuthConfig(authConfig AuthConfig) {
+	key := normalizeAuthKey(authConfig.ServerAddress)
+	authConfig.ServerAddress = key
+	c.AuthConfigs[key] = authConfig
+}
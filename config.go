@@ -0,0 +1,162 @@
+// Package dockerconfig implements reading and writing of the Docker CLI's
+// config.json, including the legacy .dockercfg format it replaced.
+package dockerconfig
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/gigablah/dockerconfig/types"
+)
+
+// AuthConfig contains authorization information for connecting to a
+// registry. It is an alias of types.AuthConfig so that the credentials
+// subpackage can depend on the type without importing this package back.
+type AuthConfig = types.AuthConfig
+
+// ProxyConfig is an alias of types.ProxyConfig so that subpackages can
+// depend on the type without importing this package back.
+type ProxyConfig = types.ProxyConfig
+
+// ConfigReadWriter is implemented by the version-specific wrappers
+// (configFileV1, configFileV2) that know how to (de)serialize a
+// ConfigFile's on-disk representation.
+type ConfigReadWriter interface {
+	LoadFromReader(io.Reader) error
+	SaveToWriter(io.Writer) error
+}
+
+// ConfigFile represents a docker configuration file, in either its legacy
+// (.dockercfg) or current (config.json) form.
+type ConfigFile struct {
+	AuthConfigs map[string]AuthConfig `json:"auths"`
+	PsFormat    string                `json:"psFormat,omitempty"`
+
+	// CredentialsStore is the name of a docker-credential-<name> helper
+	// used for every registry that isn't listed in CredentialHelpers.
+	CredentialsStore string `json:"credsStore,omitempty"`
+	// CredentialHelpers maps a registry hostname to the name of the
+	// docker-credential-<name> helper that owns its credentials.
+	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
+
+	// HTTPHeaders are extra headers injected into every registry and
+	// engine request made on behalf of this config file.
+	HTTPHeaders map[string]string `json:"HttpHeaders,omitempty"`
+	// Proxies maps a host (or "default") to the proxy settings used when
+	// talking to it.
+	Proxies map[string]ProxyConfig `json:"proxies,omitempty"`
+
+	configDir string
+	filename  string
+	version   int
+}
+
+// configDir is the directory new ConfigFiles are rooted in by default. It
+// can be overridden with SetConfigDir, or with the DOCKER_CONFIG
+// environment variable.
+var configDir = os.Getenv("DOCKER_CONFIG")
+
+// SetConfigDir sets the directory new ConfigFiles are rooted in.
+func SetConfigDir(dir string) {
+	configDir = dir
+}
+
+func getHomeDir() string {
+	home, _ := homedir.Dir()
+	return home
+}
+
+// NewConfigFile creates a new ConfigFile backed by the given filename,
+// rooted in the currently configured directory.
+func NewConfigFile(fn string) *ConfigFile {
+	return &ConfigFile{
+		AuthConfigs: make(map[string]AuthConfig),
+		configDir:   configDir,
+		filename:    fn,
+		version:     2,
+	}
+}
+
+// ConfigDir returns the directory this config file lives in.
+func (c *ConfigFile) ConfigDir() string {
+	if c.version == 1 {
+		return (&configFileV1{ConfigFile: c}).ConfigDir()
+	}
+	return (&configFileV2{ConfigFile: c}).ConfigDir()
+}
+
+// Filename returns the full path to this config file.
+func (c *ConfigFile) Filename() string {
+	if c.version == 1 {
+		return (&configFileV1{ConfigFile: c}).Filename()
+	}
+	return (&configFileV2{ConfigFile: c}).Filename()
+}
+
+// GetAuthConfigs returns the full set of auth entries stored in this
+// config file, keyed by server address.
+func (c *ConfigFile) GetAuthConfigs() map[string]AuthConfig {
+	return c.AuthConfigs
+}
+
+// Save writes the config file back to disk, in whichever format it was
+// loaded as (or configFileV2 for a freshly created one).
+func (c *ConfigFile) Save() error {
+	if c.version == 1 {
+		return save(&configFileV1{ConfigFile: c})
+	}
+	return save(&configFileV2{ConfigFile: c})
+}
+
+type versionedConfig interface {
+	ConfigReadWriter
+	ConfigDir() string
+	Filename() string
+}
+
+// Load reads the config file out of configDirOverride (or the currently
+// configured directory, if empty), preferring the current config.json
+// format and falling back to the legacy .dockercfg format. A missing file
+// is not an error; an empty ConfigFile is returned instead.
+func Load(configDirOverride string) (*ConfigFile, error) {
+	if configDirOverride != "" {
+		SetConfigDir(configDirOverride)
+	}
+
+	cfg := NewConfigFile(configFileNameV2)
+
+	v2 := &configFileV2{ConfigFile: cfg}
+	if _, err := os.Stat(v2.Filename()); err == nil {
+		f, err := os.Open(v2.Filename())
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := v2.LoadFromReader(f); err != nil {
+			return nil, err
+		}
+		cfg.version = 2
+		return cfg, nil
+	}
+
+	v1 := &configFileV1{ConfigFile: cfg}
+	legacyFilename := filepath.Join(v1.ConfigDir(), configFileNameV1)
+	if _, err := os.Stat(legacyFilename); err == nil {
+		f, err := os.Open(legacyFilename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := v1.LoadFromReader(f); err != nil {
+			return nil, err
+		}
+		cfg.version = 1
+		return cfg, nil
+	}
+
+	cfg.version = 2
+	return cfg, nil
+}
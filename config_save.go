@@ -0,0 +1,81 @@
+package dockerconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// save writes c's config out atomically: it renders it to a tempfile
+// next to the destination and renames that over the final path, so a
+// process that dies mid-write can never leave config.json truncated or
+// half-written.
+//
+// The destination may be a symlink (e.g. a secret manager pointing
+// ~/.docker/config.json somewhere else); save writes through the link's
+// target rather than replacing the link itself. A dangling symlink is
+// tolerated: save creates the target file once its parent directory
+// exists, instead of failing.
+func save(c versionedConfig) error {
+	if err := os.MkdirAll(c.ConfigDir(), 0700); err != nil {
+		return err
+	}
+
+	filename, err := resolveConfigSymlink(c.Filename())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once the rename below has succeeded
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := c.SaveToWriter(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return renameConfig(tmpName, filename)
+}
+
+// resolveConfigSymlink follows path if it is a symlink, returning the
+// file save should actually write to. A non-symlink path is returned
+// unchanged; a dangling symlink is followed to its (not yet existing)
+// target instead of being treated as an error.
+func resolveConfigSymlink(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	target, lerr := os.Readlink(path)
+	if lerr != nil {
+		// path isn't a symlink at all; it simply doesn't exist yet.
+		return path, nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return target, nil
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package dockerconfig
+
+import "os"
+
+// renameConfig replaces filename with tmpName. On POSIX systems
+// os.Rename is already atomic, even when filename already exists.
+func renameConfig(tmpName, filename string) error {
+	return os.Rename(tmpName, filename)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package dockerconfig
+
+import "os"
+
+// renameConfig replaces filename with tmpName. Windows cannot atomically
+// rename onto an existing file, so the destination is removed first;
+// there is a brief window where no file exists at filename.
+func renameConfig(tmpName, filename string) error {
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
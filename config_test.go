@@ -1,7 +1,12 @@
 package dockerconfig
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -397,7 +402,7 @@ func TestJsonReaderNoFile(t *testing.T) {
 
 	ac := config.AuthConfigs["https://index.docker.io/v1/"]
 	if ac.Email != "user@example.com" || ac.Username != "joejoe" || ac.Password != "hello" {
-		t.Fatalf("Missing data from parsing:\n%q", config)
+		t.Fatalf("Missing data from parsing:\n%+v", config)
 	}
 }
 
@@ -412,7 +417,7 @@ func TestOldJsonReaderNoFile(t *testing.T) {
 
 	ac := config.AuthConfigs["https://index.docker.io/v1/"]
 	if ac.Email != "user@example.com" || ac.Username != "joejoe" || ac.Password != "hello" {
-		t.Fatalf("Missing data from parsing:\n%q", config)
+		t.Fatalf("Missing data from parsing:\n%+v", config)
 	}
 }
 
@@ -492,3 +497,378 @@ func TestLegacyJsonSaveWithNoFile(t *testing.T) {
 		t.Fatalf("Should not have saved in new form: %s", string(buf))
 	}
 }
+
+func TestGetAuthConfigHubAliases(t *testing.T) {
+	config := NewConfigFile("")
+	config.AuthConfigs[defaultIndexServer] = AuthConfig{Username: "joejoe", ServerAddress: defaultIndexServer}
+
+	aliases := []string{
+		defaultIndexServer,
+		"index.docker.io",
+		"registry-1.docker.io",
+		"docker.io",
+		"https://registry-1.docker.io/v2/",
+	}
+	for _, alias := range aliases {
+		ac, err := config.GetAuthConfig(alias)
+		if err != nil {
+			t.Fatalf("Expected %q to resolve to the Hub entry, got error: %q", alias, err)
+		}
+		if ac.Username != "joejoe" {
+			t.Fatalf("Expected %q to resolve to the Hub entry, got: %+v", alias, ac)
+		}
+	}
+}
+
+func TestGetAuthConfigPrivateRegistryWithPort(t *testing.T) {
+	config := NewConfigFile("")
+	config.AuthConfigs["myregistry.example.com:5000"] = AuthConfig{Username: "joejoe"}
+
+	ac, err := config.GetAuthConfig("https://myregistry.example.com:5000/v2/")
+	if err != nil {
+		t.Fatalf("Expected a match for the private registry, got error: %q", err)
+	}
+	if ac.Username != "joejoe" {
+		t.Fatalf("Expected the private registry entry, got: %+v", ac)
+	}
+}
+
+func TestSetAuthConfigHubAlias(t *testing.T) {
+	config := NewConfigFile("")
+	config.SetAuthConfig(AuthConfig{Username: "joejoe", ServerAddress: "https://index.docker.io/v1/"})
+
+	if _, ok := config.AuthConfigs[defaultIndexServer]; !ok {
+		t.Fatalf("Expected SetAuthConfig to store under the canonical Hub entry")
+	}
+}
+
+func TestLoadDanglingSymlink(t *testing.T) {
+	tmpHome, err := ioutil.TempDir("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpHome)
+
+	SetConfigDir(tmpHome)
+
+	target := filepath.Join(tmpHome, "secret-manager", configFileNameV2)
+	link := filepath.Join(tmpHome, configFileNameV2)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Load(tmpHome)
+	if err != nil {
+		t.Fatalf("Failed loading through a dangling symlink: %q", err)
+	}
+
+	config.AuthConfigs["https://example.com"] = AuthConfig{Username: "joejoe"}
+	if err := config.Save(); err != nil {
+		t.Fatalf("Failed saving through a dangling symlink: %q", err)
+	}
+
+	buf, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Save should have written through the symlink to %s: %q", target, err)
+	}
+	if !strings.Contains(string(buf), "am9lam9lOg==") {
+		t.Fatalf("Expected saved content to contain the base64-encoded auth for joejoe, got: %s", string(buf))
+	}
+	if _, err := os.Lstat(link); err != nil {
+		t.Fatalf("Save should have left the symlink itself in place: %q", err)
+	}
+}
+
+// failingVersionedConfig is a versionedConfig whose SaveToWriter writes a
+// truncated document and then fails, as if the process died mid-write.
+type failingVersionedConfig struct {
+	dir      string
+	filename string
+}
+
+func (f *failingVersionedConfig) ConfigDir() string { return f.dir }
+func (f *failingVersionedConfig) Filename() string  { return filepath.Join(f.dir, f.filename) }
+
+func (f *failingVersionedConfig) LoadFromReader(r io.Reader) error { return nil }
+
+func (f *failingVersionedConfig) SaveToWriter(w io.Writer) error {
+	if _, err := io.WriteString(w, `{"auths":{`); err != nil {
+		return err
+	}
+	return fmt.Errorf("simulated crash mid-write")
+}
+
+func TestSaveSurvivesPartialWrite(t *testing.T) {
+	tmpHome, err := ioutil.TempDir("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpHome)
+
+	fn := filepath.Join(tmpHome, configFileNameV2)
+	original := `{"auths":{"https://example.com":{"auth":"b3JpZ2luYWw6"}}}`
+	if err := ioutil.WriteFile(fn, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash mid-write: save()'s SaveToWriter only gets a
+	// truncated document out before failing. The tempfile-then-rename
+	// guarantee means the bad write must never replace the good config
+	// already on disk.
+	f := &failingVersionedConfig{dir: tmpHome, filename: configFileNameV2}
+	if err := save(f); err == nil {
+		t.Fatal("Expected save to propagate the SaveToWriter error")
+	}
+
+	buf, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != original {
+		t.Fatalf("A failed write corrupted the saved config: %s", string(buf))
+	}
+
+	entries, err := ioutil.ReadDir(tmpHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected the failed write's tempfile to be cleaned up, found: %v", entries)
+	}
+}
+
+func TestParseProxyConfigPerHostOverridesDefault(t *testing.T) {
+	config := NewConfigFile("")
+	config.Proxies = map[string]ProxyConfig{
+		"default": {HTTPProxy: "http://default:8080", NoProxy: "localhost"},
+		"example.com": {
+			HTTPProxy: "http://example:8080",
+		},
+	}
+
+	result := config.ParseProxyConfig("example.com", nil)
+	if result["HTTP_PROXY"] == nil || *result["HTTP_PROXY"] != "http://example:8080" {
+		t.Fatalf("Expected per-host HTTP_PROXY to win, got %v", result["HTTP_PROXY"])
+	}
+	if result["NO_PROXY"] != nil {
+		t.Fatalf("Expected no NO_PROXY since neither the host entry nor env set one, got %v", *result["NO_PROXY"])
+	}
+
+	result = config.ParseProxyConfig("other.example.com", nil)
+	if result["HTTP_PROXY"] == nil || *result["HTTP_PROXY"] != "http://default:8080" {
+		t.Fatalf("Expected unmatched host to fall back to the default entry, got %v", result["HTTP_PROXY"])
+	}
+	if result["NO_PROXY"] == nil || *result["NO_PROXY"] != "localhost" {
+		t.Fatalf("Expected NO_PROXY from the default entry, got %v", result["NO_PROXY"])
+	}
+}
+
+func TestParseProxyConfigFileOverridesEnv(t *testing.T) {
+	config := NewConfigFile("")
+	config.Proxies = map[string]ProxyConfig{
+		"default": {HTTPProxy: "http://fromfile:8080"},
+	}
+	env := map[string]string{
+		"HTTP_PROXY":  "http://fromenv:8080",
+		"https_proxy": "http://fromenv:8443",
+	}
+
+	result := config.ParseProxyConfig("example.com", env)
+	if result["HTTP_PROXY"] == nil || *result["HTTP_PROXY"] != "http://fromfile:8080" {
+		t.Fatalf("Expected file config to override env, got %v", result["HTTP_PROXY"])
+	}
+	if result["HTTPS_PROXY"] == nil || *result["HTTPS_PROXY"] != "http://fromenv:8443" {
+		t.Fatalf("Expected lower-case env var to fill in an unset entry, got %v", result["HTTPS_PROXY"])
+	}
+}
+
+func TestHTTPHeadersForURL(t *testing.T) {
+	config := NewConfigFile("")
+	config.HTTPHeaders = map[string]string{
+		"User-Agent": "my-client/1.0",
+	}
+
+	u, err := url.Parse("https://example.com/v2/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := config.HTTPHeadersForURL(u)
+	if headers.Get("User-Agent") != "my-client/1.0" {
+		t.Fatalf("Expected configured header to be present, got %v", headers)
+	}
+}
+
+// credentialHelperProcessEnv is set in the parent test process (and
+// inherited by the exec'd child) to signal that this binary should
+// behave as a fake docker-credential-<name> helper instead of running
+// go test, so that SaveToWriter's hand-off to a CredentialsStore can be
+// exercised end-to-end without a real helper on PATH.
+const credentialHelperProcessEnv = "DOCKERCONFIG_FAKE_CREDENTIAL_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(credentialHelperProcessEnv) != "" {
+		runFakeCredentialHelper()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeCredentialHelper implements just enough of the
+// docker-credential-helpers wire protocol to accept a "store" call and
+// remember it was called, by recording the raw request it received to
+// the file named by its last argument.
+func runFakeCredentialHelper() {
+	if len(os.Args) < 3 || os.Args[len(os.Args)-2] != "store" {
+		os.Exit(2)
+	}
+	recordPath := os.Args[len(os.Args)-1]
+	body, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(recordPath, body, 0600); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// installFakeCredentialHelper puts a tiny shell script on PATH under the
+// name docker-credential-<name> that execs the running test binary with
+// recordPath appended, so the "store" call it's given is recorded there;
+// ConfigFile.GetCredentialsStore resolves "name" to it via NewNativeStore.
+func installFakeCredentialHelper(t *testing.T, name, recordPath string) func() {
+	t.Helper()
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "fake-credential-helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "docker-credential-"+name)
+	contents := "#!/bin/sh\nexec " + self + " \"$@\" \"" + recordPath + "\"\n"
+	if err := ioutil.WriteFile(script, []byte(contents), 0700); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	oldPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+oldPath)
+	os.Setenv(credentialHelperProcessEnv, "1")
+
+	return func() {
+		os.Setenv("PATH", oldPath)
+		os.Unsetenv(credentialHelperProcessEnv)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSaveToWriterPushesHelperOwnedEntryToStore(t *testing.T) {
+	tmpHome, err := ioutil.TempDir("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpHome)
+
+	record := filepath.Join(tmpHome, "store-call.json")
+	cleanup := installFakeCredentialHelper(t, "fake", record)
+	defer cleanup()
+
+	SetConfigDir(tmpHome)
+	config, err := Load(tmpHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.CredentialsStore = "fake"
+	config.AuthConfigs["https://example.com"] = AuthConfig{
+		ServerAddress: "https://example.com",
+		Username:      "joejoe",
+		Password:      "hello",
+	}
+
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	recorded, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("Expected the credentials store to have been called: %v", err)
+	}
+	if !strings.Contains(string(recorded), "joejoe") {
+		t.Fatalf("Expected the helper-owned entry to reach the store, got: %s", string(recorded))
+	}
+
+	buf, err := ioutil.ReadFile(config.Filename())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "joejoe") || strings.Contains(string(buf), "hello") {
+		t.Fatalf("Expected only an empty placeholder for the helper-owned entry, got: %s", string(buf))
+	}
+
+	var saved struct {
+		Auths map[string]AuthConfig `json:"auths"`
+	}
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		t.Fatal(err)
+	}
+	placeholder, ok := saved.Auths["https://example.com"]
+	if !ok {
+		t.Fatalf("Expected a placeholder entry for the helper-owned server, got: %s", string(buf))
+	}
+	if placeholder != (AuthConfig{}) {
+		t.Fatalf("Expected an empty placeholder entry, got: %+v", placeholder)
+	}
+}
+
+func TestIdentityTokenRoundTrip(t *testing.T) {
+	js := `{"auths":{"https://example.com":{"identitytoken":"tok123","registrytoken":"should-not-round-trip"}}}`
+
+	config := &configFileV2{ConfigFile: NewConfigFile("")}
+	if err := config.LoadFromReader(strings.NewReader(js)); err != nil {
+		t.Fatal(err)
+	}
+
+	ac := config.AuthConfigs["https://example.com"]
+	if ac.IdentityToken != "tok123" {
+		t.Fatalf("Expected IdentityToken to survive loading, got %+v", ac)
+	}
+	if ac.Username != "" || ac.Password != "" {
+		t.Fatalf("Expected no username/password to be decoded for an identity token entry, got %+v", ac)
+	}
+
+	// RegistryToken isn't part of the on-disk format; simulate what a
+	// caller would set after an OAuth2 exchange, then make sure it never
+	// gets persisted.
+	ac.RegistryToken = "bearer-token"
+	config.AuthConfigs["https://example.com"] = ac
+
+	var buf bytes.Buffer
+	if err := config.SaveToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := buf.String()
+	if strings.Contains(saved, "bearer-token") || strings.Contains(saved, "registrytoken") {
+		t.Fatalf("RegistryToken must never be persisted, got: %s", saved)
+	}
+	if !strings.Contains(saved, "tok123") {
+		t.Fatalf("Expected IdentityToken to survive saving, got: %s", saved)
+	}
+	if strings.Contains(saved, `"username"`) || strings.Contains(saved, `"password"`) {
+		t.Fatalf("Expected no username/password to leak to disk for an identity token entry, got: %s", saved)
+	}
+
+	reloaded := &configFileV2{ConfigFile: NewConfigFile("")}
+	if err := reloaded.LoadFromReader(strings.NewReader(saved)); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.AuthConfigs["https://example.com"].IdentityToken != "tok123" {
+		t.Fatalf("Expected IdentityToken to survive a load->save->load round trip, got %+v", reloaded.AuthConfigs["https://example.com"])
+	}
+}
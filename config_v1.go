@@ -0,0 +1,109 @@
+package dockerconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	configFileNameV1 = ".dockercfg"
+
+	defaultIndexServer = "https://index.docker.io/v1/"
+)
+
+type configFileV1 struct {
+	*ConfigFile
+	ConfigReadWriter `json:"-"`
+}
+
+func (c *configFileV1) ConfigDir() string {
+	configDir := c.configDir
+	if configDir == "" {
+		configDir = getHomeDir()
+	}
+	return configDir
+}
+
+func (c *configFileV1) Filename() string {
+	filename := c.filename
+	if filename == "" {
+		filename = configFileNameV1
+	}
+	return filepath.Join(c.ConfigDir(), filename)
+}
+
+// LoadFromReader reads a legacy .dockercfg file, which is either a plain
+// JSON map of server address to AuthConfig, or the even older ini-style
+// format used before that.
+func (c *configFileV1) LoadFromReader(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &c.AuthConfigs); err == nil {
+		for addr, ac := range c.AuthConfigs {
+			ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
+			if err != nil {
+				return err
+			}
+			ac.Auth = ""
+			ac.ServerAddress = addr
+			c.AuthConfigs[addr] = ac
+		}
+		return nil
+	}
+
+	arr := strings.Split(string(b), "\n")
+	if len(arr) < 2 {
+		return fmt.Errorf("The Auth config file is empty")
+	}
+
+	authConfig := AuthConfig{}
+
+	origAuth := strings.Split(arr[0], " = ")
+	if len(origAuth) != 2 {
+		return fmt.Errorf("Invalid Auth config file")
+	}
+	authConfig.Username, authConfig.Password, err = DecodeAuth(origAuth[1])
+	if err != nil {
+		return err
+	}
+
+	origEmail := strings.Split(arr[1], " = ")
+	if len(origEmail) != 2 {
+		return fmt.Errorf("Invalid Auth config file")
+	}
+	authConfig.Email = origEmail[1]
+	authConfig.ServerAddress = defaultIndexServer
+
+	c.AuthConfigs[defaultIndexServer] = authConfig
+	return nil
+}
+
+// SaveToWriter writes the config back out as a plain JSON map of server
+// address to AuthConfig, without the "auths" wrapper the current format
+// uses.
+func (c *configFileV1) SaveToWriter(w io.Writer) error {
+	tmpAuthConfigs := make(map[string]AuthConfig, len(c.AuthConfigs))
+	for addr, authConfig := range c.AuthConfigs {
+		authCopy := authConfig
+		authCopy.Auth = EncodeAuth(&authCopy)
+		authCopy.Username = ""
+		authCopy.Password = ""
+		authCopy.ServerAddress = ""
+		authCopy.RegistryToken = ""
+		tmpAuthConfigs[addr] = authCopy
+	}
+
+	data, err := json.MarshalIndent(tmpAuthConfigs, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"io"
 	"path/filepath"
+
+	"github.com/gigablah/dockerconfig/credentials"
 )
 
 const (
@@ -37,9 +39,11 @@ func (c *configFileV2) LoadFromReader(r io.Reader) error {
 	}
 	var err error
 	for addr, ac := range c.AuthConfigs {
-		ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
-		if err != nil {
-			return err
+		if ac.IdentityToken == "" {
+			ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
+			if err != nil {
+				return err
+			}
 		}
 		ac.Auth = ""
 		ac.ServerAddress = addr
@@ -53,11 +57,27 @@ func (c *configFileV2) SaveToWriter(w io.Writer) error {
 	tmpAuthConfigs := make(map[string]AuthConfig, len(c.AuthConfigs))
 	for k, authConfig := range c.AuthConfigs {
 		authCopy := authConfig
-		// encode and save the authstring, while blanking out the original fields
-		authCopy.Auth = EncodeAuth(&authCopy)
+		if helper := c.credentialHelper(k); helper != "" {
+			// A credential helper owns this entry: push it there and
+			// leave nothing but an empty placeholder in the JSON.
+			authCopy.ServerAddress = k
+			if err := credentials.NewNativeStore(helper).Store(authCopy); err != nil {
+				return err
+			}
+			tmpAuthConfigs[k] = AuthConfig{}
+			continue
+		}
+		if authCopy.IdentityToken == "" {
+			// encode and save the authstring, while blanking out the original fields
+			authCopy.Auth = EncodeAuth(&authCopy)
+		}
+		// An identity token carries its own auth state, so there is
+		// never a username/password to keep alongside one.
 		authCopy.Username = ""
 		authCopy.Password = ""
 		authCopy.ServerAddress = ""
+		// RegistryToken is a bearer token good for this process only.
+		authCopy.RegistryToken = ""
 		tmpAuthConfigs[k] = authCopy
 	}
 
@@ -0,0 +1,20 @@
+package credentials
+
+import "errors"
+
+// errCredentialsNotFoundMessage is the exact message a native helper
+// writes to stderr when it has no credentials for a server; the native
+// store maps it to ErrCredentialsNotFound rather than a generic error.
+const errCredentialsNotFoundMessage = "credentials not found in native keychain"
+
+// ErrCredentialsNotFound is returned by the native store when its helper
+// reports that no credentials are present for the requested server
+// address. The file store's Get returns an empty AuthConfig and a nil
+// error in that case instead.
+var ErrCredentialsNotFound = errors.New(errCredentialsNotFoundMessage)
+
+// IsErrCredentialsNotFound returns true if err is (or wraps)
+// ErrCredentialsNotFound.
+func IsErrCredentialsNotFound(err error) bool {
+	return errors.Is(err, ErrCredentialsNotFound)
+}
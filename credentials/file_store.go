@@ -0,0 +1,37 @@
+package credentials
+
+import "github.com/gigablah/dockerconfig/types"
+
+// fileStore implements Store by keeping credentials directly in a
+// ConfigFile's AuthConfigs map, the way they were stored before
+// credsStore/credHelpers existed.
+type fileStore struct {
+	file ConfigFile
+}
+
+// NewFileStore creates a new file Store backed by file.
+func NewFileStore(file ConfigFile) Store {
+	return &fileStore{file: file}
+}
+
+func (c *fileStore) Get(serverAddress string) (types.AuthConfig, error) {
+	authConfig, ok := c.file.GetAuthConfigs()[serverAddress]
+	if !ok {
+		return types.AuthConfig{}, nil
+	}
+	return authConfig, nil
+}
+
+func (c *fileStore) GetAll() (map[string]types.AuthConfig, error) {
+	return c.file.GetAuthConfigs(), nil
+}
+
+func (c *fileStore) Store(authConfig types.AuthConfig) error {
+	c.file.GetAuthConfigs()[authConfig.ServerAddress] = authConfig
+	return c.file.Save()
+}
+
+func (c *fileStore) Erase(serverAddress string) error {
+	delete(c.file.GetAuthConfigs(), serverAddress)
+	return c.file.Save()
+}
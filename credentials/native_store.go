@@ -0,0 +1,125 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gigablah/dockerconfig/types"
+)
+
+// remoteCredentialsPrefix is prepended to a helper name (e.g. "osxkeychain"
+// from credsStore/credHelpers) to get the binary docker-credential-helpers
+// expects to find on PATH.
+const remoteCredentialsPrefix = "docker-credential-"
+
+// nativeStore shells out to a docker-credential-<name> helper binary,
+// speaking its JSON-on-stdio protocol.
+type nativeStore struct {
+	helperName string
+}
+
+// NewNativeStore creates a new native Store that defers to the
+// docker-credential-<helperName> binary.
+func NewNativeStore(helperName string) Store {
+	return &nativeStore{helperName: helperName}
+}
+
+func (n *nativeStore) program() string {
+	return remoteCredentialsPrefix + n.helperName
+}
+
+type credentialsRequest struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+func (n *nativeStore) exec(verb string, stdin []byte) ([]byte, error) {
+	cmd := exec.Command(n.program(), verb)
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		t := strings.TrimSpace(stderr.String())
+		if t == errCredentialsNotFoundMessage {
+			return nil, ErrCredentialsNotFound
+		}
+		return nil, fmt.Errorf("error calling %s %s: %v: %s", n.program(), verb, err, t)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (n *nativeStore) Get(serverAddress string) (types.AuthConfig, error) {
+	req, err := json.Marshal(credentialsRequest{ServerURL: serverAddress})
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+
+	out, err := n.exec("get", req)
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+
+	var resp credentialsRequest
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return types.AuthConfig{}, err
+	}
+
+	return types.AuthConfig{
+		ServerAddress: serverAddress,
+		Username:      resp.Username,
+		Password:      resp.Secret,
+	}, nil
+}
+
+func (n *nativeStore) GetAll() (map[string]types.AuthConfig, error) {
+	out, err := n.exec("list", []byte{})
+	if err != nil {
+		return nil, err
+	}
+
+	var servers map[string]string
+	if err := json.Unmarshal(out, &servers); err != nil {
+		return nil, err
+	}
+
+	authConfigs := make(map[string]types.AuthConfig, len(servers))
+	for serverAddress := range servers {
+		authConfig, err := n.Get(serverAddress)
+		if err != nil {
+			return nil, err
+		}
+		authConfigs[serverAddress] = authConfig
+	}
+	return authConfigs, nil
+}
+
+func (n *nativeStore) Store(authConfig types.AuthConfig) error {
+	req, err := json.Marshal(credentialsRequest{
+		ServerURL: authConfig.ServerAddress,
+		Username:  authConfig.Username,
+		Secret:    authConfig.Password,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = n.exec("store", req)
+	return err
+}
+
+func (n *nativeStore) Erase(serverAddress string) error {
+	req, err := json.Marshal(credentialsRequest{ServerURL: serverAddress})
+	if err != nil {
+		return err
+	}
+
+	_, err = n.exec("erase", req)
+	return err
+}
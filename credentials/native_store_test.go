@@ -0,0 +1,201 @@
+package credentials
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gigablah/dockerconfig/types"
+)
+
+// fakeHelperEnv is set in the parent test process (and inherited by the
+// exec'd child) to signal that this binary should behave as a
+// docker-credential-<name> helper instead of running go test.
+const fakeHelperEnv = "DOCKERCONFIG_FAKE_CREDENTIAL_HELPER"
+
+// fakeHelperDBEnv points the helper at the JSON file it persists its
+// (fake) keychain to, so that state survives across the separate
+// processes nativeStore execs for store/get/erase/list.
+const fakeHelperDBEnv = "DOCKERCONFIG_FAKE_CREDENTIAL_HELPER_DB"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeHelperEnv) != "" {
+		runFakeHelper()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeHelper implements just enough of the docker-credential-helpers
+// wire protocol (JSON request on stdin, JSON or stderr-message response)
+// to exercise nativeStore: get/store/erase/list, backed by a JSON file so
+// that state persists across the separate processes nativeStore execs.
+func runFakeHelper() {
+	if len(os.Args) < 2 {
+		os.Exit(2)
+	}
+	verb := os.Args[len(os.Args)-1]
+	dbPath := os.Getenv(fakeHelperDBEnv)
+
+	db := make(map[string]credentialsRequest)
+	if b, err := ioutil.ReadFile(dbPath); err == nil {
+		json.Unmarshal(b, &db)
+	}
+
+	switch verb {
+	case "store":
+		var req credentialsRequest
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			os.Exit(1)
+		}
+		db[req.ServerURL] = req
+		saveFakeHelperDB(dbPath, db)
+	case "get":
+		var req credentialsRequest
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			os.Exit(1)
+		}
+		entry, ok := db[req.ServerURL]
+		if !ok {
+			os.Stderr.WriteString(errCredentialsNotFoundMessage)
+			os.Exit(1)
+		}
+		json.NewEncoder(os.Stdout).Encode(entry)
+	case "erase":
+		var req credentialsRequest
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			os.Exit(1)
+		}
+		delete(db, req.ServerURL)
+		saveFakeHelperDB(dbPath, db)
+	case "list":
+		out := make(map[string]string, len(db))
+		for addr, req := range db {
+			out[addr] = req.Username
+		}
+		json.NewEncoder(os.Stdout).Encode(out)
+	default:
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+func saveFakeHelperDB(path string, db map[string]credentialsRequest) {
+	b, err := json.Marshal(db)
+	if err != nil {
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		os.Exit(1)
+	}
+}
+
+// installFakeHelper puts a copy of the running test binary on PATH under
+// the name docker-credential-<name>, so that NewNativeStore(name) resolves
+// to it, and arranges for that copy to run as runFakeHelper instead of
+// go test when exec'd. It returns the Store and a cleanup func.
+func installFakeHelper(t *testing.T, name string) (Store, func()) {
+	t.Helper()
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	selfBytes, err := ioutil.ReadFile(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "fake-credential-helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, remoteCredentialsPrefix+name)
+	if err := ioutil.WriteFile(dst, selfBytes, 0700); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	db, err := ioutil.TempFile(dir, "db.json")
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	db.Close()
+
+	oldPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+oldPath)
+	os.Setenv(fakeHelperEnv, "1")
+	os.Setenv(fakeHelperDBEnv, db.Name())
+
+	return NewNativeStore(name), func() {
+		os.Setenv("PATH", oldPath)
+		os.Unsetenv(fakeHelperEnv)
+		os.Unsetenv(fakeHelperDBEnv)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNativeStoreGetNotFound(t *testing.T) {
+	store, cleanup := installFakeHelper(t, "getnotfound")
+	defer cleanup()
+
+	_, err := store.Get("https://example.com")
+	if !IsErrCredentialsNotFound(err) {
+		t.Fatalf("Expected ErrCredentialsNotFound, got %v", err)
+	}
+}
+
+func TestNativeStoreStoreGetEraseRoundTrip(t *testing.T) {
+	store, cleanup := installFakeHelper(t, "roundtrip")
+	defer cleanup()
+
+	authConfig := types.AuthConfig{
+		ServerAddress: "https://example.com",
+		Username:      "joejoe",
+		Password:      "hello",
+	}
+	if err := store.Store(authConfig); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	got, err := store.Get("https://example.com")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Username != "joejoe" || got.Password != "hello" {
+		t.Fatalf("Expected stored credentials back, got %+v", got)
+	}
+
+	if err := store.Erase("https://example.com"); err != nil {
+		t.Fatalf("Erase failed: %v", err)
+	}
+
+	if _, err := store.Get("https://example.com"); !IsErrCredentialsNotFound(err) {
+		t.Fatalf("Expected ErrCredentialsNotFound after erase, got %v", err)
+	}
+}
+
+func TestNativeStoreGetAll(t *testing.T) {
+	store, cleanup := installFakeHelper(t, "getall")
+	defer cleanup()
+
+	for _, addr := range []string{"https://example.com", "https://other.example.com"} {
+		if err := store.Store(types.AuthConfig{ServerAddress: addr, Username: "joejoe"}); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	all, err := store.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 entries, got %d: %+v", len(all), all)
+	}
+	if all["https://example.com"].Username != "joejoe" {
+		t.Fatalf("Missing expected entry: %+v", all)
+	}
+}
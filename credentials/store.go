@@ -0,0 +1,27 @@
+// Package credentials provides the docker-credential-helpers integration:
+// a Store abstraction for looking up, persisting and erasing registry
+// credentials, backed either by the config file itself or by an external
+// docker-credential-<name> helper binary.
+package credentials
+
+import "github.com/gigablah/dockerconfig/types"
+
+// Store is the interface for a credentials store, whatever its backing.
+type Store interface {
+	// Get returns the credentials stored for serverAddress.
+	Get(serverAddress string) (types.AuthConfig, error)
+	// GetAll returns all the credentials stored, keyed by server address.
+	GetAll() (map[string]types.AuthConfig, error)
+	// Store saves the given credentials.
+	Store(authConfig types.AuthConfig) error
+	// Erase removes the credentials stored for serverAddress.
+	Erase(serverAddress string) error
+}
+
+// ConfigFile is the subset of dockerconfig.ConfigFile's behavior the file
+// store needs. It is declared here, rather than imported, so that this
+// package does not need to depend on the package that embeds it.
+type ConfigFile interface {
+	GetAuthConfigs() map[string]types.AuthConfig
+	Save() error
+}
@@ -0,0 +1,25 @@
+package dockerconfig
+
+import "github.com/gigablah/dockerconfig/credentials"
+
+// credentialHelper returns the name of the docker-credential-<name>
+// helper that owns serverAddress, preferring a per-registry entry in
+// CredentialHelpers over the catch-all CredentialsStore. It returns "" if
+// neither applies, meaning credentials live in the file itself.
+func (c *ConfigFile) credentialHelper(serverAddress string) string {
+	if helper, ok := c.CredentialHelpers[serverAddress]; ok && helper != "" {
+		return helper
+	}
+	return c.CredentialsStore
+}
+
+// GetCredentialsStore returns the credentials.Store that owns credentials
+// for serverAddress: a native store backed by CredentialHelpers or
+// CredentialsStore if either names a helper for it, or the file itself
+// otherwise.
+func (c *ConfigFile) GetCredentialsStore(serverAddress string) credentials.Store {
+	if helper := c.credentialHelper(serverAddress); helper != "" {
+		return credentials.NewNativeStore(helper)
+	}
+	return credentials.NewFileStore(c)
+}
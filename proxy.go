@@ -0,0 +1,76 @@
+package dockerconfig
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyEnv lists the environment variables a ParseProxyConfig result is
+// keyed by, in their canonical (upper-case) form.
+var proxyEnv = []string{
+	"HTTP_PROXY",
+	"HTTPS_PROXY",
+	"NO_PROXY",
+	"FTP_PROXY",
+	"ALL_PROXY",
+}
+
+// ParseProxyConfig resolves the proxy settings that should be used to
+// reach host, merging the file's Proxies entry for host (falling back to
+// its "default" entry) over env, the process environment. A key maps to
+// nil in the result if neither the file nor env sets it.
+//
+// The returned map is keyed by the upper-case proxy environment variable
+// names (HTTP_PROXY, HTTPS_PROXY, NO_PROXY, FTP_PROXY, ALL_PROXY), so
+// callers can feed it straight into an exec.Cmd's environment.
+func (c *ConfigFile) ParseProxyConfig(host string, env map[string]string) map[string]*string {
+	cfgKey := host
+	if _, ok := c.Proxies[host]; !ok {
+		cfgKey = "default"
+	}
+
+	config := c.Proxies[cfgKey]
+	permitted := map[string]*string{
+		"HTTP_PROXY":  &config.HTTPProxy,
+		"HTTPS_PROXY": &config.HTTPSProxy,
+		"NO_PROXY":    &config.NoProxy,
+		"FTP_PROXY":   &config.FTPProxy,
+		"ALL_PROXY":   &config.AllProxy,
+	}
+
+	for _, k := range proxyEnv {
+		if *permitted[k] != "" {
+			continue
+		}
+		if v, ok := env[k]; ok {
+			*permitted[k] = v
+		} else if v, ok := env[strings.ToLower(k)]; ok {
+			*permitted[k] = v
+		}
+	}
+
+	result := make(map[string]*string, len(proxyEnv))
+	for _, k := range proxyEnv {
+		if *permitted[k] == "" {
+			result[k] = nil
+			continue
+		}
+		v := *permitted[k]
+		result[k] = &v
+	}
+	return result
+}
+
+// HTTPHeadersForURL returns the HTTPHeaders configured on this file as an
+// http.Header, ready to be merged into the headers of a request to u (or
+// installed on an http.Client's transport). u is accepted for parity with
+// ParseProxyConfig's per-host lookup; HTTPHeaders itself is not currently
+// scoped by host.
+func (c *ConfigFile) HTTPHeadersForURL(u *url.URL) http.Header {
+	headers := make(http.Header, len(c.HTTPHeaders))
+	for k, v := range c.HTTPHeaders {
+		headers.Set(k, v)
+	}
+	return headers
+}
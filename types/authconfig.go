@@ -0,0 +1,27 @@
+// Package types holds data structures that are shared between the
+// dockerconfig package and its subpackages (such as credentials), so that
+// those subpackages can depend on the data without importing the package
+// that embeds it back.
+package types
+
+// AuthConfig contains authorization information for connecting to a
+// registry.
+type AuthConfig struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth"`
+
+	// Email is an optional value associated with the account.
+	Email string `json:"email,omitempty"`
+
+	ServerAddress string `json:"serveraddress,omitempty"`
+
+	// IdentityToken is used to authenticate the user and get an access
+	// token for the registry.
+	IdentityToken string `json:"identitytoken,omitempty"`
+
+	// RegistryToken is a bearer token to be sent to a registry. It is
+	// never persisted to disk; it only lives for the duration of a
+	// process that obtained one via an OAuth2 token exchange.
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
@@ -0,0 +1,12 @@
+package types
+
+// ProxyConfig holds the proxy settings used when talking to a particular
+// host (or the "default" entry applied to every host without one of its
+// own).
+type ProxyConfig struct {
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	NoProxy    string `json:"noProxy,omitempty"`
+	FTPProxy   string `json:"ftpProxy,omitempty"`
+	AllProxy   string `json:"allProxy,omitempty"`
+}